@@ -22,6 +22,17 @@ import (
 // ManifestName is the manifest file name used by dep.
 const ManifestName = "Gopkg.toml"
 
+// Limits applied when reading a manifest, including the manifests of
+// dependencies. Tools that read untrusted or unusually large manifests may
+// adjust them before loading any projects.
+var (
+	// MaxManifestSize is the largest manifest, in bytes, that dep will read.
+	MaxManifestSize = 4 << 20
+	// MaxManifestNesting is the deepest bracket or brace nesting that dep
+	// will hand to the TOML parser, which recurses on nesting.
+	MaxManifestNesting = 64
+)
+
 // Errors
 var (
 	errInvalidConstraint   = errors.Errorf("%q must be a TOML array of tables", "constraint")
@@ -32,6 +43,10 @@ var (
 	errInvalidPruneProject = errors.Errorf("%q must be a TOML array of tables", "prune.project")
 	errInvalidMetadata     = errors.New("metadata should be a TOML table")
 
+	errManifestTooLarge = errors.New("manifest is too large")
+	errManifestTooDeep  = errors.New("manifest is nested too deeply")
+	errManifestLimit    = errors.New("manifest limits must be positive")
+
 	errInvalidProjectRoot = errors.New("ProjectRoot name validation failed")
 
 	errInvalidPruneValue = errors.New("prune options values must be booleans")
@@ -335,11 +350,34 @@ func ValidateProjectRoots(c *Ctx, m *Manifest, sm gps.SourceManager) error {
 
 // readManifest returns a Manifest read from r and a slice of validation warnings.
 func readManifest(r io.Reader) (*Manifest, []error, error) {
+	maxSize, maxNesting := MaxManifestSize, MaxManifestNesting
+	if maxSize <= 0 {
+		return nil, nil, errors.Wrapf(errManifestLimit, "MaxManifestSize is %d", maxSize)
+	}
+	if maxNesting <= 0 {
+		return nil, nil, errors.Wrapf(errManifestLimit, "MaxManifestNesting is %d", maxNesting)
+	}
+
 	buf := &bytes.Buffer{}
-	_, err := buf.ReadFrom(r)
+	_, err := buf.ReadFrom(io.LimitReader(r, int64(maxSize)))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "unable to read byte stream")
 	}
+	if buf.Len() == maxSize {
+		// Probe for one more byte rather than reading maxSize+1, which would
+		// overflow for a limit of math.MaxInt64.
+		var probe [1]byte
+		n, err := io.ReadFull(r, probe[:])
+		if n > 0 {
+			return nil, nil, errors.Wrapf(errManifestTooLarge, "more than %d bytes", maxSize)
+		}
+		if err != io.EOF {
+			return nil, nil, errors.Wrap(err, "unable to read byte stream")
+		}
+	}
+	if err = checkManifestNesting(buf.Bytes(), maxNesting); err != nil {
+		return nil, nil, err
+	}
 
 	warns, err := validateManifest(buf.String())
 	if err != nil {
@@ -361,6 +399,50 @@ func readManifest(r io.Reader) (*Manifest, []error, error) {
 	return m, warns, nil
 }
 
+// checkManifestNesting returns an error caused by errManifestTooDeep if the
+// bracket and brace nesting in data, ignoring strings and comments, exceeds
+// limit.
+func checkManifestNesting(data []byte, limit int) error {
+	var depth int
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '#':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case '"', '\'':
+			delim := data[i : i+1]
+			if triple := bytes.Repeat(delim, 3); bytes.HasPrefix(data[i:], triple) {
+				delim = triple
+			}
+			i += len(delim)
+			for i < len(data) && !bytes.HasPrefix(data[i:], delim) {
+				// Only basic strings support escapes.
+				if c == '"' && data[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			// A multi-line string may end with up to two quotes of content
+			// right before its closing delimiter, as in """x"""".
+			for extra := 0; len(delim) == 3 && extra < 2 && i+3 < len(data) && data[i+3] == c; extra++ {
+				i++
+			}
+			i += len(delim) - 1
+		case '[', '{':
+			depth++
+			if depth > limit {
+				return errors.Wrapf(errManifestTooDeep, "more than %d levels", limit)
+			}
+		case ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
 func fromRawManifest(raw rawManifest, buf *bytes.Buffer) (*Manifest, error) {
 	m := NewManifest()
 
@@ -6,16 +6,17 @@ package dep
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/golang/dep/gps"
 	"github.com/golang/dep/internal/test"
+	"github.com/pkg/errors"
 )
 
 func TestReadManifest(t *testing.T) {
@@ -133,6 +134,93 @@ func TestReadManifestErrors(t *testing.T) {
 	}
 }
 
+func TestReadManifestLimits(t *testing.T) {
+	nested := func(n int) string {
+		return "[metadata]\n  foo = " + strings.Repeat("[", n) + strings.Repeat("]", n) + "\n"
+	}
+	constraint := "[[constraint]]\n  name = \"github.com/foo/bar\"\n  version = \"1.0.0\"\n"
+
+	tests := []struct {
+		name       string
+		input      string
+		maxSize    int
+		maxNesting int
+		wantErr    error
+	}{
+		{"too large", strings.Repeat("#", MaxManifestSize+1), MaxManifestSize, MaxManifestNesting, errManifestTooLarge},
+		{"exactly max size", strings.Repeat("#", MaxManifestSize), MaxManifestSize, MaxManifestNesting, nil},
+		{"too deep", nested(MaxManifestNesting + 1), MaxManifestSize, MaxManifestNesting, errManifestTooDeep},
+		{"exactly max nesting", nested(MaxManifestNesting), MaxManifestSize, MaxManifestNesting, nil},
+		{"lowered size", strings.Repeat("#", 11), 10, MaxManifestNesting, errManifestTooLarge},
+		{"raised size", strings.Repeat("#", MaxManifestSize+1), MaxManifestSize + 1, MaxManifestNesting, nil},
+		{"lowered nesting", nested(3), MaxManifestSize, 2, errManifestTooDeep},
+		{"raised nesting", nested(MaxManifestNesting + 1), MaxManifestSize, MaxManifestNesting + 1, nil},
+		{"max int size", constraint, math.MaxInt64, MaxManifestNesting, nil},
+		{"max int nesting", constraint, MaxManifestSize, math.MaxInt64, nil},
+		{"zero size", constraint, 0, MaxManifestNesting, errManifestLimit},
+		{"negative size", constraint, -1, MaxManifestNesting, errManifestLimit},
+		{"zero nesting", constraint, MaxManifestSize, 0, errManifestLimit},
+		{"negative nesting", constraint, MaxManifestSize, -1, errManifestLimit},
+	}
+
+	defer func(size, nesting int) {
+		MaxManifestSize, MaxManifestNesting = size, nesting
+	}(MaxManifestSize, MaxManifestNesting)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			MaxManifestSize, MaxManifestNesting = tc.maxSize, tc.maxNesting
+			m, _, err := readManifest(strings.NewReader(tc.input))
+			if errors.Cause(err) != tc.wantErr {
+				t.Fatalf("unexpected error %v; expected %v", err, tc.wantErr)
+			}
+			if want := strings.Count(tc.input, "[[constraint]]"); err == nil && len(m.Constraints) != want {
+				t.Fatalf("read %d constraints; expected %d", len(m.Constraints), want)
+			}
+		})
+	}
+}
+
+func TestCheckManifestNesting(t *testing.T) {
+	const limit = 4
+	deep := strings.Repeat("[", limit+1)
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"exactly limit", strings.Repeat("[", limit) + strings.Repeat("]", limit), nil},
+		{"too deep", deep, errManifestTooDeep},
+		{"closed brackets do not accumulate", strings.Repeat("[]", limit+1), nil},
+		{"brackets in comments", "# " + deep + "\n", nil},
+		{"brackets after comment", "# comment\n" + deep, errManifestTooDeep},
+		{"brackets in basic strings", `foo = "\"` + deep + `"`, nil},
+		{"brackets in literal strings", `foo = '` + deep + `'`, nil},
+		{"brackets in multi-line basic strings", "foo = \"\"\"\n\"" + deep + "\n\"\"\"", nil},
+		{"brackets in multi-line literal strings", "foo = '''\n'" + deep + "\n'''", nil},
+		{"quotes before multi-line basic string close", "foo = \"\"\"x\"\"\"\"\nbar = " + deep, errManifestTooDeep},
+		{"two quotes before multi-line basic string close", "foo = \"\"\"x\"\"\"\"\"\nbar = " + deep, errManifestTooDeep},
+		{"quotes before multi-line literal string close", "foo = '''x'''''\nbar = " + deep, errManifestTooDeep},
+		{"brackets after multi-line basic strings", "foo = \"\"\"\n\"\"\"\nbar = " + deep, errManifestTooDeep},
+		{"escaped backslash before closing quote", `foo = "\\"` + "\nbar = " + deep, errManifestTooDeep},
+		{"backslash in literal strings", `foo = '\'` + "\nbar = " + deep, errManifestTooDeep},
+		{"empty basic string", `foo = ""` + "\nbar = " + deep, errManifestTooDeep},
+		{"unterminated basic string", `foo = "` + deep, nil},
+		{"unterminated multi-line basic string", `foo = """` + deep, nil},
+		{"unterminated escape", `foo = "\`, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkManifestNesting([]byte(tc.input), limit)
+			if errors.Cause(err) != tc.wantErr {
+				t.Fatalf("unexpected error %v; expected %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateManifest(t *testing.T) {
 	cases := []struct {
 		name       string